@@ -0,0 +1,210 @@
+// Copyright (c) 2017 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"fmt"
+	"strings"
+
+	apiv2 "github.com/projectcalico/libcalico-go/lib/apis/v2"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/libcalico-go/lib/errors"
+
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CalicoNetworkPolicyToK8s converts a Calico NetworkPolicy KVPair into a
+// stock networking.k8s.io/v1 NetworkPolicy, for the subset of Calico
+// NetworkPolicy specs that round-trip through the Kubernetes NetworkPolicy
+// API: Allow rules with selector- and CIDR-based peers only. It returns a
+// cerrors.ErrorPolicyConversionNotRepresentable if the spec uses
+// Calico-only extensions (policy Order, HTTP match, ServiceAccount
+// selectors, ICMP match, Deny/Log/Pass actions, etc), so that callers can
+// fall back to storing the policy as a Calico CRD instead.
+func (c Converter) CalicoNetworkPolicyToK8s(kvp *model.KVPair) (*netv1.NetworkPolicy, error) {
+	key := kvp.Key.(model.ResourceKey)
+	v := kvp.Value.(*apiv2.NetworkPolicy)
+	spec := v.Spec
+
+	// key.Name is the Calico-facing name, e.g. "knp.default.foo". The k8s
+	// object itself is only ever known by the bare name, "foo" - strip the
+	// prefix before writing it back, the same way Get/Delete strip it
+	// before reading.
+	_, name, err := c.ParsePolicyNameNetworkPolicy(key.Name)
+	if err != nil {
+		return nil, cerrors.ErrorPolicyConversionNotRepresentable{
+			Identifier: kvp.Key,
+			Reason:     fmt.Sprintf("could not parse k8s-backed policy name: %s", err),
+		}
+	}
+
+	if spec.Order != nil {
+		return nil, cerrors.ErrorPolicyConversionNotRepresentable{
+			Identifier: kvp.Key,
+			Reason:     "NetworkPolicy has a non-nil Order, which has no equivalent in networking.k8s.io/v1",
+		}
+	}
+
+	podSelector, err := k8sLabelSelectorFromCalicoSelector(spec.Selector)
+	if err != nil {
+		return nil, cerrors.ErrorPolicyConversionNotRepresentable{Identifier: kvp.Key, Reason: err.Error()}
+	}
+
+	var policyTypes []netv1.PolicyType
+	for _, t := range spec.Types {
+		switch t {
+		case apiv2.PolicyTypeIngress:
+			policyTypes = append(policyTypes, netv1.PolicyTypeIngress)
+		case apiv2.PolicyTypeEgress:
+			policyTypes = append(policyTypes, netv1.PolicyTypeEgress)
+		}
+	}
+
+	var ingress []netv1.NetworkPolicyIngressRule
+	for _, r := range spec.IngressRules {
+		if err := checkRuleRepresentable(r); err != nil {
+			return nil, cerrors.ErrorPolicyConversionNotRepresentable{Identifier: kvp.Key, Reason: err.Error()}
+		}
+		peers, err := k8sPeersFromCalicoEntityRule(r.Source)
+		if err != nil {
+			return nil, cerrors.ErrorPolicyConversionNotRepresentable{Identifier: kvp.Key, Reason: err.Error()}
+		}
+		ingress = append(ingress, netv1.NetworkPolicyIngressRule{From: peers})
+	}
+
+	var egress []netv1.NetworkPolicyEgressRule
+	for _, r := range spec.EgressRules {
+		if err := checkRuleRepresentable(r); err != nil {
+			return nil, cerrors.ErrorPolicyConversionNotRepresentable{Identifier: kvp.Key, Reason: err.Error()}
+		}
+		peers, err := k8sPeersFromCalicoEntityRule(r.Destination)
+		if err != nil {
+			return nil, cerrors.ErrorPolicyConversionNotRepresentable{Identifier: kvp.Key, Reason: err.Error()}
+		}
+		egress = append(egress, netv1.NetworkPolicyEgressRule{To: peers})
+	}
+
+	return &netv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       key.Namespace,
+			ResourceVersion: kvp.Revision,
+		},
+		Spec: netv1.NetworkPolicySpec{
+			PodSelector: *podSelector,
+			PolicyTypes: policyTypes,
+			Ingress:     ingress,
+			Egress:      egress,
+		},
+	}, nil
+}
+
+// checkRuleRepresentable returns an error describing the first
+// Calico-only feature found on the rule that has no equivalent in
+// networking.k8s.io/v1.
+func checkRuleRepresentable(r apiv2.Rule) error {
+	if r.Action != apiv2.Allow {
+		return fmt.Errorf("rule action %q has no equivalent in networking.k8s.io/v1, only 'Allow' is representable", r.Action)
+	}
+	if r.HTTP != nil {
+		return fmt.Errorf("HTTP match criteria have no equivalent in networking.k8s.io/v1")
+	}
+	if r.ICMP != nil {
+		return fmt.Errorf("ICMP match criteria have no equivalent in networking.k8s.io/v1")
+	}
+	if r.Source.ServiceAccounts != nil || r.Destination.ServiceAccounts != nil {
+		return fmt.Errorf("ServiceAccount selectors have no equivalent in networking.k8s.io/v1")
+	}
+	if r.Source.NotSelector != "" || r.Destination.NotSelector != "" || len(r.Source.NotNets) != 0 || len(r.Destination.NotNets) != 0 {
+		return fmt.Errorf("negated selectors/nets have no equivalent in networking.k8s.io/v1")
+	}
+	if r.Protocol != nil || r.NotProtocol != nil {
+		return fmt.Errorf("protocol match criteria are not representable in networking.k8s.io/v1 by this converter")
+	}
+	if len(r.Source.Ports) != 0 || len(r.Source.NotPorts) != 0 || len(r.Destination.Ports) != 0 || len(r.Destination.NotPorts) != 0 {
+		return fmt.Errorf("port match criteria are not representable in networking.k8s.io/v1 by this converter")
+	}
+	return nil
+}
+
+// k8sPeersFromCalicoEntityRule translates the selector- and CIDR-based
+// peers of a Calico EntityRule into the equivalent list of k8s
+// NetworkPolicyPeers. A rule with neither a Selector nor Nets matches
+// everything, which is represented by a nil peer list.
+func k8sPeersFromCalicoEntityRule(e apiv2.EntityRule) ([]netv1.NetworkPolicyPeer, error) {
+	if e.Selector == "" && len(e.Nets) == 0 {
+		return nil, nil
+	}
+	if e.Selector != "" && len(e.Nets) != 0 {
+		// Calico matches peers that satisfy both the selector and the nets,
+		// whereas k8s ORs the entries of a peer list together.
+		return nil, fmt.Errorf("a rule with both a selector and nets has no equivalent in networking.k8s.io/v1")
+	}
+
+	var peers []netv1.NetworkPolicyPeer
+	if e.Selector != "" {
+		sel, err := k8sLabelSelectorFromCalicoSelector(e.Selector)
+		if err != nil {
+			return nil, err
+		}
+		peers = append(peers, netv1.NetworkPolicyPeer{PodSelector: sel})
+	}
+	for _, n := range e.Nets {
+		peers = append(peers, netv1.NetworkPolicyPeer{IPBlock: &netv1.IPBlock{CIDR: n}})
+	}
+	return peers, nil
+}
+
+// k8sLabelSelectorFromCalicoSelector translates a Calico selector
+// expression into a k8s metav1.LabelSelector. Only straightforward
+// conjunctions of label equality are representable; anything else (has(),
+// "!=", set membership, etc) is rejected.
+func k8sLabelSelectorFromCalicoSelector(selector string) (*metav1.LabelSelector, error) {
+	if selector == "" || selector == "all()" {
+		return &metav1.LabelSelector{}, nil
+	}
+	requirements, err := parseSimpleLabelSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("selector %q is not representable in networking.k8s.io/v1: %s", selector, err)
+	}
+	return requirements, nil
+}
+
+// parseSimpleLabelSelector handles the subset of Calico selector syntax
+// that maps directly onto a k8s label equality match: a "&&"-joined
+// conjunction of `key == 'value'` terms. Anything richer (has(), "in",
+// "!=", "||", etc) is rejected since it has no k8s equivalent.
+func parseSimpleLabelSelector(selector string) (*metav1.LabelSelector, error) {
+	if strings.Contains(selector, "||") {
+		return nil, fmt.Errorf("\"||\" has no equivalent in a k8s label selector")
+	}
+
+	labels := map[string]string{}
+	for _, term := range strings.Split(selector, "&&") {
+		term = strings.TrimSpace(term)
+		parts := strings.SplitN(term, "==", 2)
+		if len(parts) != 2 || strings.Contains(parts[1], "==") {
+			return nil, fmt.Errorf("unsupported term %q", term)
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.Trim(strings.TrimSpace(parts[1]), `'"`)
+		if key == "" {
+			return nil, fmt.Errorf("unsupported term %q", term)
+		}
+		labels[key] = value
+	}
+	return &metav1.LabelSelector{MatchLabels: labels}, nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2017 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"testing"
+
+	apiv2 "github.com/projectcalico/libcalico-go/lib/apis/v2"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/libcalico-go/lib/numorstring"
+)
+
+func TestCalicoNetworkPolicyToK8sRoundTrip(t *testing.T) {
+	c := Converter{}
+
+	pol := apiv2.NewNetworkPolicy()
+	pol.Spec.Selector = "role == 'backend'"
+	pol.Spec.Types = []apiv2.PolicyType{apiv2.PolicyTypeIngress}
+	pol.Spec.IngressRules = []apiv2.Rule{
+		{
+			Action: apiv2.Allow,
+			Source: apiv2.EntityRule{Selector: "role == 'frontend'"},
+		},
+	}
+
+	kvp := &model.KVPair{
+		Key: model.ResourceKey{
+			Name:      "knp.default.allow-backend",
+			Namespace: "default",
+			Kind:      apiv2.KindNetworkPolicy,
+		},
+		Value:    pol,
+		Revision: "1234",
+	}
+
+	np, err := c.CalicoNetworkPolicyToK8s(kvp)
+	if err != nil {
+		t.Fatalf("unexpected error converting to k8s NetworkPolicy: %s", err)
+	}
+	// The k8s object itself must carry the bare name: the "knp.default."
+	// prefix is a Calico-facing convention applied on the way back out by
+	// K8sNetworkPolicyToCalico, not part of the k8s object's own name.
+	if np.ObjectMeta.Name != "allow-backend" || np.ObjectMeta.Namespace != "default" {
+		t.Fatalf("unexpected ObjectMeta on converted NetworkPolicy: %+v", np.ObjectMeta)
+	}
+
+	back, err := c.K8sNetworkPolicyToCalico(np)
+	if err != nil {
+		t.Fatalf("unexpected error converting back to Calico NetworkPolicy: %s", err)
+	}
+	backKey := back.Key.(model.ResourceKey)
+	if backKey.Name != "knp.default.allow-backend" {
+		t.Fatalf("key name did not round-trip: got %q, want %q", backKey.Name, "knp.default.allow-backend")
+	}
+	backPol := back.Value.(*apiv2.NetworkPolicy)
+	if backPol.Spec.Selector != pol.Spec.Selector {
+		t.Fatalf("selector did not round-trip: got %q, want %q", backPol.Spec.Selector, pol.Spec.Selector)
+	}
+}
+
+func TestCalicoNetworkPolicyToK8sRejectsOrSelector(t *testing.T) {
+	c := Converter{}
+
+	pol := apiv2.NewNetworkPolicy()
+	pol.Spec.Selector = "role == 'a' || role == 'b'"
+
+	kvp := &model.KVPair{
+		Key: model.ResourceKey{
+			Name:      "knp.default.or-selector",
+			Namespace: "default",
+			Kind:      apiv2.KindNetworkPolicy,
+		},
+		Value: pol,
+	}
+
+	_, err := c.CalicoNetworkPolicyToK8s(kvp)
+	if err == nil {
+		t.Fatalf("expected an error converting a policy with an \"||\" selector")
+	}
+}
+
+func TestCalicoNetworkPolicyToK8sRejectsPorts(t *testing.T) {
+	c := Converter{}
+
+	pol := apiv2.NewNetworkPolicy()
+	pol.Spec.Types = []apiv2.PolicyType{apiv2.PolicyTypeIngress}
+	pol.Spec.IngressRules = []apiv2.Rule{
+		{
+			Action:      apiv2.Allow,
+			Destination: apiv2.EntityRule{Ports: []numorstring.Port{{MinPort: 80, MaxPort: 80}}},
+		},
+	}
+
+	kvp := &model.KVPair{
+		Key: model.ResourceKey{
+			Name:      "knp.default.ports",
+			Namespace: "default",
+			Kind:      apiv2.KindNetworkPolicy,
+		},
+		Value: pol,
+	}
+
+	_, err := c.CalicoNetworkPolicyToK8s(kvp)
+	if err == nil {
+		t.Fatalf("expected an error converting a policy with port match criteria")
+	}
+}
+
+func TestCalicoNetworkPolicyToK8sRejectsOrder(t *testing.T) {
+	c := Converter{}
+
+	order := float64(10)
+	pol := apiv2.NewNetworkPolicy()
+	pol.Spec.Order = &order
+
+	kvp := &model.KVPair{
+		Key: model.ResourceKey{
+			Name:      "knp.default.ordered",
+			Namespace: "default",
+			Kind:      apiv2.KindNetworkPolicy,
+		},
+		Value: pol,
+	}
+
+	_, err := c.CalicoNetworkPolicyToK8s(kvp)
+	if err == nil {
+		t.Fatalf("expected an error converting a policy with a non-nil Order")
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2017 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// GenericResourceOptions describes a CRD-backed resource whose converter
+// is nothing more than a copy of ObjectMeta.Name/Namespace/Spec to and
+// from the equivalent apiv2 type - the pattern every hand-written
+// CustomK8sResourceConverter in this package (e.g. the old
+// BGPPeerConverter) implemented by hand. NewGenericResourceClient
+// synthesizes that converter once, by reflection, instead of requiring a
+// new converter type per resource.
+type GenericResourceOptions struct {
+	ClientSet  *kubernetes.Clientset
+	RESTClient *rest.RESTClient
+
+	Name        string
+	Resource    string
+	Description string
+
+	// CRDType and CRDListType are zero values of the Go types stored in
+	// the CRD, e.g. crd.BGPPeer{} / crd.BGPPeerList{}.
+	CRDType     interface{}
+	CRDListType interface{}
+
+	// CalicoType is a zero value of the apiv2 Go type the converter
+	// produces/consumes, e.g. apiv2.BGPPeer{}. It must declare
+	// ObjectMeta and Spec fields with the same shape as CRDType.
+	CalicoType interface{}
+
+	Kind       string
+	Namespaced bool
+}
+
+// NewGenericResourceClient builds a K8sResourceClient for a CRD resource
+// whose conversion is exactly "copy ObjectMeta and Spec", synthesizing the
+// CustomK8sResourceConverter by reflection instead of requiring a
+// hand-written one per resource.
+func NewGenericResourceClient(opts GenericResourceOptions) K8sResourceClient {
+	return &customK8sResourceClient{
+		clientSet:       opts.ClientSet,
+		restClient:      opts.RESTClient,
+		name:            opts.Name,
+		resource:        opts.Resource,
+		description:     opts.Description,
+		k8sResourceType: reflect.TypeOf(opts.CRDType),
+		k8sListType:     reflect.TypeOf(opts.CRDListType),
+		converter: genericConverter{
+			crdType:    reflect.TypeOf(opts.CRDType),
+			calicoType: reflect.TypeOf(opts.CalicoType),
+			kind:       opts.Kind,
+		},
+		namespaced: opts.Namespaced,
+	}
+}
+
+// genericConverter implements CustomK8sResourceConverter by copying
+// ObjectMeta and Spec between a CRD type and its equivalent apiv2 type via
+// reflection.
+type genericConverter struct {
+	crdType    reflect.Type
+	calicoType reflect.Type
+	kind       string
+}
+
+func (c genericConverter) ListInterfaceToKey(l model.ListInterface) model.Key {
+	pl := l.(model.ResourceListOptions)
+	if pl.Name != "" {
+		return model.ResourceKey{Name: pl.Name, Kind: pl.Kind, Namespace: pl.Namespace}
+	}
+	return nil
+}
+
+func (c genericConverter) KeyToName(k model.Key) (string, error) {
+	return k.(model.ResourceKey).Name, nil
+}
+
+func (c genericConverter) NameToKey(name string) (model.Key, error) {
+	return model.ResourceKey{Name: name, Kind: c.kind}, nil
+}
+
+func (c genericConverter) ToKVPair(r CustomK8sResource) (*model.KVPair, error) {
+	crdVal := reflect.ValueOf(r)
+	if crdVal.Kind() == reflect.Ptr {
+		crdVal = crdVal.Elem()
+	}
+	metaField := crdVal.FieldByName("ObjectMeta")
+	if !metaField.IsValid() {
+		return nil, fmt.Errorf("%s has no ObjectMeta field", c.crdType)
+	}
+	objMeta, ok := metaField.Interface().(metav1.ObjectMeta)
+	if !ok {
+		return nil, fmt.Errorf("%s has no ObjectMeta field", c.crdType)
+	}
+
+	calico := reflect.New(c.calicoType)
+	calico.Elem().FieldByName("ObjectMeta").Set(reflect.ValueOf(metav1.ObjectMeta{
+		Name:      objMeta.Name,
+		Namespace: objMeta.Namespace,
+	}))
+	calico.Elem().FieldByName("Spec").Set(crdVal.FieldByName("Spec"))
+
+	return &model.KVPair{
+		Key: model.ResourceKey{
+			Name:      objMeta.Name,
+			Namespace: objMeta.Namespace,
+			Kind:      c.kind,
+		},
+		Value:    calico.Interface(),
+		Revision: objMeta.ResourceVersion,
+	}, nil
+}
+
+func (c genericConverter) FromKVPair(kvp *model.KVPair) (CustomK8sResource, error) {
+	calicoVal := reflect.ValueOf(kvp.Value)
+	if calicoVal.Kind() == reflect.Ptr {
+		calicoVal = calicoVal.Elem()
+	}
+	metaField := calicoVal.FieldByName("ObjectMeta")
+	if !metaField.IsValid() {
+		return nil, fmt.Errorf("%s has no ObjectMeta field", c.calicoType)
+	}
+	objMeta, ok := metaField.Interface().(metav1.ObjectMeta)
+	if !ok {
+		return nil, fmt.Errorf("%s has no ObjectMeta field", c.calicoType)
+	}
+
+	crd := reflect.New(c.crdType)
+	crd.Elem().FieldByName("ObjectMeta").Set(reflect.ValueOf(metav1.ObjectMeta{
+		Name:            objMeta.Name,
+		Namespace:       objMeta.Namespace,
+		ResourceVersion: kvp.Revision,
+	}))
+	crd.Elem().FieldByName("Spec").Set(calicoVal.FieldByName("Spec"))
+
+	res, ok := crd.Interface().(CustomK8sResource)
+	if !ok {
+		return nil, fmt.Errorf("%s does not implement CustomK8sResource", c.crdType)
+	}
+	return res, nil
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2017 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/projectcalico/libcalico-go/lib/apiv2"
+	"github.com/projectcalico/libcalico-go/lib/backend/k8s/crd"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenericConverterRoundTripsBGPPeer(t *testing.T) {
+	conv := genericConverter{
+		crdType:    reflect.TypeOf(crd.BGPPeer{}),
+		calicoType: reflect.TypeOf(apiv2.BGPPeer{}),
+		kind:       apiv2.KindBGPPeer,
+	}
+
+	original := &crd.BGPPeer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "peer-a",
+			ResourceVersion: "42",
+		},
+	}
+
+	kvp, err := conv.ToKVPair(original)
+	if err != nil {
+		t.Fatalf("unexpected error from ToKVPair: %s", err)
+	}
+	key := kvp.Key.(model.ResourceKey)
+	if key.Name != "peer-a" || key.Kind != apiv2.KindBGPPeer {
+		t.Fatalf("unexpected key from ToKVPair: %+v", key)
+	}
+	if kvp.Revision != "42" {
+		t.Fatalf("unexpected revision from ToKVPair: %s", kvp.Revision)
+	}
+
+	back, err := conv.FromKVPair(kvp)
+	if err != nil {
+		t.Fatalf("unexpected error from FromKVPair: %s", err)
+	}
+	roundTripped := back.(*crd.BGPPeer)
+	if roundTripped.ObjectMeta.Name != original.ObjectMeta.Name {
+		t.Fatalf("name did not round-trip: got %q, want %q", roundTripped.ObjectMeta.Name, original.ObjectMeta.Name)
+	}
+}
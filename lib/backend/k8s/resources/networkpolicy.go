@@ -16,6 +16,7 @@ package resources
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"reflect"
@@ -58,10 +59,25 @@ func NewNetworkPolicyClient(c *kubernetes.Clientset, r *rest.RESTClient) K8sReso
 	}
 }
 
+// networkPolicyCRDClient is the subset of K8sResourceClient that
+// networkPolicyClient drives against the CRD-backed NetworkPolicy source.
+// *customK8sResourceClient satisfies it; naming it here (rather than
+// typing the crdClient field as the concrete *customK8sResourceClient)
+// lets tests substitute a fake CRD source for the List/Watch fallback
+// without standing up a real REST server.
+type networkPolicyCRDClient interface {
+	Create(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error)
+	Update(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error)
+	Delete(ctx context.Context, key model.Key, revision string) (*model.KVPair, error)
+	Get(ctx context.Context, key model.Key, revision string) (*model.KVPair, error)
+	List(ctx context.Context, list model.ListInterface, revision string) (*model.KVPairList, error)
+	Watch(ctx context.Context, list model.ListInterface, revision string) (api.WatchInterface, error)
+}
+
 // Implements the api.Client interface for NetworkPolicys.
 type networkPolicyClient struct {
 	clientSet *kubernetes.Clientset
-	crdClient *customK8sResourceClient
+	crdClient networkPolicyCRDClient
 	converter conversion.Converter
 }
 
@@ -69,11 +85,15 @@ func (c *networkPolicyClient) Create(ctx context.Context, kvp *model.KVPair) (*m
 	log.Debug("Received Create request on NetworkPolicy type")
 	key := kvp.Key.(model.ResourceKey)
 	if strings.HasPrefix(key.Name, "knp.default.") {
-		// We don't support Create of a Kubernetes NetworkPolicy.
-		return nil, cerrors.ErrorOperationNotSupported{
-			Identifier: kvp.Key,
-			Operation:  "Create",
+		np, err := c.converter.CalicoNetworkPolicyToK8s(kvp)
+		if err != nil {
+			return nil, err
 		}
+		created, err := c.clientSet.NetworkingV1().NetworkPolicies(key.Namespace).Create(np)
+		if err != nil {
+			return nil, K8sErrorToCalico(err, kvp.Key)
+		}
+		return c.converter.K8sNetworkPolicyToCalico(created)
 	}
 	return c.crdClient.Create(ctx, kvp)
 }
@@ -82,16 +102,32 @@ func (c *networkPolicyClient) Update(ctx context.Context, kvp *model.KVPair) (*m
 	log.Debug("Received Update request on NetworkPolicy type")
 	key := kvp.Key.(model.ResourceKey)
 	if strings.HasPrefix(key.Name, "knp.default.") {
-		// We don't support Update of a Kubernetes NetworkPolicy.
-		return nil, cerrors.ErrorOperationNotSupported{
-			Identifier: kvp.Key,
-			Operation:  "Update",
+		np, err := c.converter.CalicoNetworkPolicyToK8s(kvp)
+		if err != nil {
+			return nil, err
 		}
+		updated, err := c.clientSet.NetworkingV1().NetworkPolicies(key.Namespace).Update(np)
+		if err != nil {
+			return nil, K8sErrorToCalico(err, kvp.Key)
+		}
+		return c.converter.K8sNetworkPolicyToCalico(updated)
 	}
 	return c.crdClient.Update(ctx, kvp)
 }
 
 func (c *networkPolicyClient) Apply(kvp *model.KVPair) (*model.KVPair, error) {
+	log.Debug("Received Apply request on NetworkPolicy type")
+	key := kvp.Key.(model.ResourceKey)
+	if strings.HasPrefix(key.Name, "knp.default.") {
+		updated, err := c.Update(context.Background(), kvp)
+		if err != nil {
+			if _, ok := err.(cerrors.ErrorResourceDoesNotExist); ok {
+				return c.Create(context.Background(), kvp)
+			}
+			return nil, err
+		}
+		return updated, nil
+	}
 	return nil, cerrors.ErrorOperationNotSupported{
 		Identifier: kvp.Key,
 		Operation:  "Apply",
@@ -102,11 +138,20 @@ func (c *networkPolicyClient) Delete(ctx context.Context, key model.Key, revisio
 	log.Debug("Received Delete request on NetworkPolicy type")
 	k := key.(model.ResourceKey)
 	if strings.HasPrefix(k.Name, "knp.default.") {
-		// We don't support Delete of a Kubernetes NetworkPolicy.
-		return nil, cerrors.ErrorOperationNotSupported{
-			Identifier: key,
-			Operation:  "Delete",
+		namespace, policyName, err := c.converter.ParsePolicyNameNetworkPolicy(k.Name)
+		if err != nil {
+			return nil, cerrors.ErrorResourceDoesNotExist{Err: err, Identifier: k}
+		}
+
+		existing, err := c.Get(ctx, k, revision)
+		if err != nil {
+			return nil, err
 		}
+
+		if err := c.clientSet.NetworkingV1().NetworkPolicies(namespace).Delete(policyName, &metav1.DeleteOptions{}); err != nil {
+			return nil, K8sErrorToCalico(err, key)
+		}
+		return existing, nil
 	}
 	return c.crdClient.Delete(ctx, key, revision)
 }
@@ -137,9 +182,27 @@ func (c *networkPolicyClient) Get(ctx context.Context, key model.Key, revision s
 	}
 }
 
+// NetworkPolicyListOptions extends model.ResourceListOptions with a Limit
+// for server-side chunked listing of the k8s-native NetworkPolicy source.
+// Embedding ResourceListOptions lets it satisfy model.ListInterface while
+// falling back to the unbounded list path when Limit is left at zero.
+// Limit does not bound the CRD-backed source - see the KNOWN LIMITATION
+// comment in List.
+type NetworkPolicyListOptions struct {
+	model.ResourceListOptions
+	Limit int64
+}
+
+func networkPolicyListOptions(list model.ListInterface) (model.ResourceListOptions, int64) {
+	if pl, ok := list.(NetworkPolicyListOptions); ok {
+		return pl.ResourceListOptions, pl.Limit
+	}
+	return list.(model.ResourceListOptions), 0
+}
+
 func (c *networkPolicyClient) List(ctx context.Context, list model.ListInterface, revision string) (*model.KVPairList, error) {
 	log.Debug("Received List request on NetworkPolicy type")
-	l := list.(model.ResourceListOptions)
+	l, limit := networkPolicyListOptions(list)
 	if l.Name != "" {
 		// Exact lookup on a NetworkPolicy.
 		kvp, err := c.Get(ctx, model.ResourceKey{Name: l.Name, Namespace: l.Namespace, Kind: l.Kind}, revision)
@@ -161,52 +224,154 @@ func (c *networkPolicyClient) List(ctx context.Context, list model.ListInterface
 		}, nil
 	}
 
-	// Otherwise, list all NetworkPolicy objects in Namespace.
-	networkPolicies, err := c.clientSet.NetworkingV1().NetworkPolicies(l.Namespace).List(metav1.ListOptions{})
+	// `revision` doubles as the opaque continuation token from a previous
+	// page: decode it to find out which source we're still paging and
+	// where in that source's ResourceVersion-pinned list we left off.
+	k8sRV, innerContinue, k8sDone, err := decodeNetworkPolicyContinueToken(revision)
 	if err != nil {
-		return nil, K8sErrorToCalico(err, l)
+		return nil, err
 	}
 
-	// For each policy, turn it into a Policy and generate the list.
 	ret := []*model.KVPair{}
-	for _, p := range networkPolicies.Items {
-		kvp, err := c.converter.K8sNetworkPolicyToCalico(&p)
+
+	if !k8sDone {
+		// Page through the k8s-native NetworkPolicies first.
+		networkPolicies, err := c.clientSet.NetworkingV1().NetworkPolicies(l.Namespace).List(k8sPageListOptions(k8sRV, innerContinue, limit))
 		if err != nil {
-			return nil, err
+			return nil, K8sErrorToCalico(err, l)
+		}
+		if k8sRV == "" {
+			// Record the ResourceVersion observed on the first page and
+			// carry it in every token of this paginated sequence, so a
+			// later Watch can resume from that snapshot. Later pages stay
+			// on the same snapshot through their Continue tokens.
+			k8sRV = networkPolicies.ResourceVersion
+		}
+		for i := range networkPolicies.Items {
+			kvp, err := c.converter.K8sNetworkPolicyToCalico(&networkPolicies.Items[i])
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, kvp)
 		}
-		ret = append(ret, kvp)
+		if networkPolicies.Continue != "" {
+			return &model.KVPairList{
+				KVPairs:  ret,
+				Revision: encodeNetworkPolicyContinueToken(k8sRV, networkPolicies.Continue, false),
+			}, nil
+		}
+		// k8s source exhausted for this snapshot - fall through to the CRD
+		// source below in the same page.
 	}
 
-	// List all Namespaced Calico Network Policies.
-	nps, err := c.crdClient.List(ctx, l, revision)
+	// KNOWN LIMITATION: customK8sResourceClient.List has no Limit/Continue
+	// parameters, so the CRD-backed NetworkPolicies - typically the larger
+	// of the two sources in namespaces with many Calico-native policies -
+	// are always consumed in one unbounded list on the page that reaches
+	// them, and the merged sequence terminates there. Limit only bounds
+	// the k8s-native source above. Closing this gap needs Limit/Continue
+	// threaded into customK8sResourceClient.List itself (outside this
+	// file), not something this client can do on its own.
+	nps, err := c.crdClient.List(ctx, model.ResourceListOptions{Namespace: l.Namespace, Kind: l.Kind}, "")
 	if err != nil {
 		return nil, err
 	}
 	ret = append(ret, nps.KVPairs...)
 
+	// The k8s-native source is exhausted, so encode a "done" token that
+	// still carries the pinned k8sRV rather than discarding it: a caller
+	// that finishes paging and then calls Watch(ctx, list, list.Revision)
+	// to resume from this snapshot needs that ResourceVersion, or it
+	// reopens the list/watch gap pagination exists to close.
 	return &model.KVPairList{
 		KVPairs:  ret,
-		Revision: revision,
+		Revision: encodeNetworkPolicyContinueToken(k8sRV, "", true),
 	}, nil
 }
 
+// k8sPageListOptions builds the metav1.ListOptions for one page of the
+// k8s-native NetworkPolicy list. The apiserver rejects a list request that
+// sets both ResourceVersion and Continue, so k8sRV is only sent when
+// innerContinue is empty (the first page, for which List passes an empty
+// k8sRV); every later page is anchored purely by its Continue token.
+func k8sPageListOptions(k8sRV, innerContinue string, limit int64) metav1.ListOptions {
+	opts := metav1.ListOptions{Continue: innerContinue}
+	if innerContinue == "" {
+		opts.ResourceVersion = k8sRV
+	}
+	if limit > 0 {
+		opts.Limit = limit
+	}
+	return opts
+}
+
+// encodeNetworkPolicyContinueToken packs the k8s-source paging state -
+// its pinned initial ResourceVersion and inner k8s continue token - into
+// a single opaque base64 token, suitable for returning as
+// model.KVPairList.Revision and passing back in as List's revision
+// argument to resume.
+func encodeNetworkPolicyContinueToken(k8sRV, k8sContinue string, k8sDone bool) string {
+	done := "0"
+	if k8sDone {
+		done = "1"
+	}
+	raw := strings.Join([]string{k8sRV, k8sContinue, done}, "\x00")
+	return base64.StdEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeNetworkPolicyContinueToken is the inverse of
+// encodeNetworkPolicyContinueToken. An empty token means "start a fresh
+// list from the beginning of the k8s source".
+func decodeNetworkPolicyContinueToken(token string) (k8sRV, k8sContinue string, k8sDone bool, err error) {
+	if token == "" {
+		return "", "", false, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return "", "", false, fmt.Errorf("invalid NetworkPolicy continue token: %s", err)
+	}
+	parts := strings.SplitN(string(raw), "\x00", 3)
+	if len(parts) != 3 {
+		return "", "", false, fmt.Errorf("invalid NetworkPolicy continue token")
+	}
+	return parts[0], parts[1], parts[2] == "1", nil
+}
+
+// networkPolicyWatchRevision extracts the k8s ResourceVersion to watch
+// from: a revision passed to Watch is either a plain k8s ResourceVersion
+// (e.g. a watch restart, or a fresh watch with revision == "") or one of
+// List's encoded continuation tokens (the list-then-watch pattern, where
+// revision is whatever List last returned). Tokens decode cleanly;
+// anything that doesn't decode is treated as a plain ResourceVersion.
+func networkPolicyWatchRevision(revision string) string {
+	k8sRV, _, _, err := decodeNetworkPolicyContinueToken(revision)
+	if err != nil {
+		return revision
+	}
+	return k8sRV
+}
+
 func (c *networkPolicyClient) EnsureInitialized() error {
 	return nil
 }
 
 func (c *networkPolicyClient) Watch(ctx context.Context, list model.ListInterface, revision string) (api.WatchInterface, error) {
-	// TODO(doublek): We are only watching k8s backed NetworkPolicy. Will need to add
-	// the ability to watch both CRD and k8s NetworkPolicy.
-	resl := list.(model.ResourceListOptions)
+	// NetworkPolicy is backed by two independent sources: k8s-native
+	// NetworkPolicy objects and Calico CRD-backed NetworkPolicy objects.
+	// Watch both and merge the result into a single api.WatchInterface so
+	// that callers don't need to know about the split.
+	resl, _ := networkPolicyListOptions(list)
 	if len(resl.Name) != 0 {
-		return nil, fmt.Errorf("cannot watch specific resource instance: %s", list.(model.ResourceListOptions).Name)
+		return nil, fmt.Errorf("cannot watch specific resource instance: %s", resl.Name)
 	}
 
-	k8sWatch, err := c.clientSet.NetworkingV1().NetworkPolicies(resl.Namespace).Watch(metav1.ListOptions{ResourceVersion: revision})
-	if err != nil {
-		return nil, K8sErrorToCalico(err, list)
-	}
+	// revision is either a plain k8s ResourceVersion (e.g. from a watch
+	// restart) or one of List's encoded continuation tokens (e.g. from the
+	// standard list-then-watch pattern, resuming from a finished List's
+	// Revision) - unwrap the latter down to the k8sRV it carries.
+	k8sRV := networkPolicyWatchRevision(revision)
 
+	k8sWatch, err := c.clientSet.NetworkingV1().NetworkPolicies(resl.Namespace).Watch(metav1.ListOptions{ResourceVersion: k8sRV})
 	if err != nil {
 		return nil, K8sErrorToCalico(err, list)
 	}
@@ -217,8 +382,15 @@ func (c *networkPolicyClient) Watch(ctx context.Context, list model.ListInterfac
 		}
 		return c.converter.K8sNetworkPolicyToCalico(np)
 	}
-	return newK8sWatcherConverter(ctx, converter, k8sWatch), nil
-	// return c.crdClient.Watch(ctx, list, revision)
+	k8sConvertingWatch := newK8sWatcherConverter(ctx, converter, k8sWatch)
+
+	crdWatch, err := c.crdClient.Watch(ctx, resl, k8sRV)
+	if err != nil {
+		k8sConvertingWatch.Stop()
+		return nil, err
+	}
+
+	return newMergedNetworkPolicyWatcher(k8sConvertingWatch, crdWatch), nil
 }
 
 // NetworkPolicyConverter implements the K8sResourceConverter interface.
@@ -0,0 +1,102 @@
+// Copyright (c) 2017 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/libcalico-go/lib/backend/api"
+)
+
+// resultsBufSize matches the buffering used by newK8sWatcherConverter so
+// that a slow consumer of the merged channel doesn't apply back-pressure to
+// either substream independently.
+const resultsBufSize = 100
+
+// mergedNetworkPolicyWatcher fans-in the k8s-native NetworkPolicy watch and
+// the Calico CRD NetworkPolicy watch into a single api.WatchInterface. The
+// two substreams have unrelated ResourceVersion streams, so no attempt is
+// made to reconcile them here: if either substream's watch terminates (e.g.
+// on a "too old resource version" error), a synthetic error event is sent
+// on the merged channel and the whole merged watch stops, so that the
+// caller (normally the syncer) knows to restart it - relisting both
+// sources - rather than this watcher silently reconnecting one of them on
+// its own.
+type mergedNetworkPolicyWatcher struct {
+	resultChan chan api.WatchEvent
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+
+	k8sWatch api.WatchInterface
+	crdWatch api.WatchInterface
+}
+
+func newMergedNetworkPolicyWatcher(k8sWatch, crdWatch api.WatchInterface) *mergedNetworkPolicyWatcher {
+	w := &mergedNetworkPolicyWatcher{
+		resultChan: make(chan api.WatchEvent, resultsBufSize),
+		stopCh:     make(chan struct{}),
+		k8sWatch:   k8sWatch,
+		crdWatch:   crdWatch,
+	}
+	go w.pump("k8s", k8sWatch)
+	go w.pump("crd", crdWatch)
+	return w
+}
+
+func (w *mergedNetworkPolicyWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+		w.k8sWatch.Stop()
+		w.crdWatch.Stop()
+	})
+}
+
+func (w *mergedNetworkPolicyWatcher) ResultChan() <-chan api.WatchEvent {
+	return w.resultChan
+}
+
+// pump relays events from a single substream into the merged result
+// channel. If the substream terminates, a synthetic error event is sent
+// and the whole merged watch stops - the caller is expected to restart it.
+func (w *mergedNetworkPolicyWatcher) pump(name string, sub api.WatchInterface) {
+	for {
+		select {
+		case <-w.stopCh:
+			return
+		case e, ok := <-sub.ResultChan():
+			if !ok {
+				w.sendError(fmt.Errorf("%s NetworkPolicy watch terminated", name))
+				w.Stop()
+				return
+			}
+			select {
+			case w.resultChan <- e:
+			case <-w.stopCh:
+				return
+			}
+		}
+	}
+}
+
+func (w *mergedNetworkPolicyWatcher) sendError(err error) {
+	log.WithError(err).Warning("NetworkPolicy merged watch substream failed")
+	select {
+	case w.resultChan <- api.WatchEvent{Type: api.WatchError, Error: err}:
+	case <-w.stopCh:
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2017 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	apiv2 "github.com/projectcalico/libcalico-go/lib/apis/v2"
+	"github.com/projectcalico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/libcalico-go/lib/backend/k8s/conversion"
+	"github.com/projectcalico/libcalico-go/lib/backend/model"
+
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeWatch is a minimal api.WatchInterface used to inject substream
+// events directly in tests. Stop is idempotent, like real watch.Interface
+// implementations, since the merged watcher may call Stop on a substream
+// that has already closed its own channel.
+type fakeWatch struct {
+	mu      sync.Mutex
+	ch      chan api.WatchEvent
+	stopped bool
+}
+
+func newFakeWatch() *fakeWatch {
+	return &fakeWatch{ch: make(chan api.WatchEvent, 10)}
+}
+
+func (f *fakeWatch) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stopped {
+		return
+	}
+	f.stopped = true
+	close(f.ch)
+}
+
+func (f *fakeWatch) ResultChan() <-chan api.WatchEvent { return f.ch }
+
+func TestMergedNetworkPolicyWatcherFanIn(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	k8sWatch, err := clientset.NetworkingV1().NetworkPolicies("default").Watch(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to create fake k8s watch: %s", err)
+	}
+
+	client := &networkPolicyClient{clientSet: clientset, converter: conversion.Converter{}}
+	converter := func(r Resource) (*model.KVPair, error) {
+		return client.converter.K8sNetworkPolicyToCalico(r.(*netv1.NetworkPolicy))
+	}
+	k8sConvertingWatch := newK8sWatcherConverter(context.Background(), converter, k8sWatch)
+
+	crdWatch := newFakeWatch()
+
+	w := newMergedNetworkPolicyWatcher(k8sConvertingWatch, crdWatch)
+	defer w.Stop()
+
+	np := &netv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "k8s-pol", Namespace: "default", ResourceVersion: "10"}}
+	if _, err := clientset.NetworkingV1().NetworkPolicies("default").Create(np); err != nil {
+		t.Fatalf("failed to create fake NetworkPolicy: %s", err)
+	}
+
+	crdWatch.ch <- api.WatchEvent{
+		Type: api.WatchAdded,
+		New: &model.KVPair{
+			Key:      model.ResourceKey{Name: "crd-pol", Namespace: "default", Kind: apiv2.KindNetworkPolicy},
+			Value:    apiv2.NewNetworkPolicy(),
+			Revision: "5",
+		},
+	}
+
+	seen := map[string]bool{}
+	timeout := time.After(2 * time.Second)
+	for len(seen) < 2 {
+		select {
+		case e := <-w.ResultChan():
+			if e.New != nil {
+				seen[e.New.Key.(model.ResourceKey).Name] = true
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for merged events, saw: %v", seen)
+		}
+	}
+
+	if !seen["knp.default.k8s-pol"] || !seen["crd-pol"] {
+		t.Fatalf("expected events from both the k8s and CRD sources, got: %v", seen)
+	}
+}
+
+func TestMergedNetworkPolicyWatcherStopPropagatesToBothSubstreams(t *testing.T) {
+	k8sWatch := newFakeWatch()
+	crdWatch := newFakeWatch()
+
+	w := newMergedNetworkPolicyWatcher(k8sWatch, crdWatch)
+
+	w.Stop()
+
+	select {
+	case _, ok := <-k8sWatch.ch:
+		if ok {
+			t.Fatalf("expected k8s substream channel to be closed after Stop()")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for k8s substream to stop")
+	}
+
+	select {
+	case _, ok := <-crdWatch.ch:
+		if ok {
+			t.Fatalf("expected crd substream channel to be closed after Stop()")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for crd substream to stop")
+	}
+}
+
+func TestMergedNetworkPolicyWatcherEmitsErrorAndStopsWhenASubstreamTerminates(t *testing.T) {
+	k8sWatch := newFakeWatch()
+	crdWatch := newFakeWatch()
+
+	w := newMergedNetworkPolicyWatcher(k8sWatch, crdWatch)
+
+	// Simulate the crd substream terminating unexpectedly (e.g. the
+	// underlying watch hit a terminal error), without going through
+	// w.Stop().
+	crdWatch.Stop()
+
+	timeout := time.After(2 * time.Second)
+	select {
+	case e := <-w.ResultChan():
+		if e.Type != api.WatchError || e.Error == nil {
+			t.Fatalf("expected a synthetic error event, got: %+v", e)
+		}
+	case <-timeout:
+		t.Fatalf("timed out waiting for the synthetic error event")
+	}
+
+	// The merged watch should stop itself - and, in particular, stop the
+	// other (still-healthy) substream - rather than silently reconnecting.
+	select {
+	case _, ok := <-k8sWatch.ch:
+		if ok {
+			t.Fatalf("expected the k8s substream to be stopped once the crd substream terminated")
+		}
+	case <-timeout:
+		t.Fatalf("timed out waiting for the k8s substream to stop")
+	}
+}
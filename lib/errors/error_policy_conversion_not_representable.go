@@ -0,0 +1,31 @@
+// Copyright (c) 2017 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "fmt"
+
+// ErrorPolicyConversionNotRepresentable is returned when a Calico
+// NetworkPolicy cannot be converted into a stock networking.k8s.io/v1
+// NetworkPolicy because its spec uses a Calico-only feature (e.g. policy
+// Order, HTTP match, ServiceAccount selectors). Callers should treat this
+// as a signal to fall back to storing the policy as a Calico CRD.
+type ErrorPolicyConversionNotRepresentable struct {
+	Identifier interface{}
+	Reason     string
+}
+
+func (e ErrorPolicyConversionNotRepresentable) Error() string {
+	return fmt.Sprintf("%v cannot be represented as a networking.k8s.io/v1 NetworkPolicy: %s", e.Identifier, e.Reason)
+}